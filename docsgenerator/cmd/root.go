@@ -37,6 +37,16 @@ var (
 	startStepFlagVal            int
 	endStepFlagVal              int
 	leaveGeneratedFilesFlagVal  bool
+
+	platformsFlagVal []string
+	builderFlagVal   string
+	cacheFromFlagVal []string
+	cacheToFlagVal   []string
+	pushFlagVal      bool
+
+	cacheRegistryFlagVal string
+	verifyLockFlagVal    bool
+	writeLockFlagVal     bool
 )
 
 var rootCmd = &cobra.Command{
@@ -49,6 +59,14 @@ var rootCmd = &cobra.Command{
 			StartStep:            startStepFlagVal,
 			EndStep:              endStepFlagVal,
 			LeaveGeneratedFiles:  leaveGeneratedFilesFlagVal,
+			Platforms:            platformsFlagVal,
+			Builder:              generator.Builder(builderFlagVal),
+			CacheFrom:            cacheFromFlagVal,
+			CacheTo:              cacheToFlagVal,
+			Push:                 pushFlagVal,
+			CacheRegistry:        cacheRegistryFlagVal,
+			VerifyLock:           verifyLockFlagVal,
+			WriteLock:            writeLockFlagVal,
 		}
 		return generator.Generate(inputDirFlagVal, outputDirFlagVal, baseImageFlagVal, params, cmd.OutOrStdout())
 	},
@@ -78,4 +96,14 @@ func init() {
 	rootCmd.Flags().IntVar(&startStepFlagVal, "start-step", -1, "start step")
 	rootCmd.Flags().IntVar(&endStepFlagVal, "end-step", -1, "end step")
 	rootCmd.Flags().BoolVar(&leaveGeneratedFilesFlagVal, "leave-generated-files", false, "do not clean up the generated intermediate files")
+
+	rootCmd.Flags().StringSliceVar(&platformsFlagVal, "platforms", nil, "comma-separated platforms to build (e.g. linux/amd64,linux/arm64); more than one requires --builder=buildx")
+	rootCmd.Flags().StringVar(&builderFlagVal, "builder", "docker", "the builder used to build the generated Docker images (docker or buildx)")
+	rootCmd.Flags().StringSliceVar(&cacheFromFlagVal, "cache-from", nil, "remote cache source(s) to consult when building")
+	rootCmd.Flags().StringSliceVar(&cacheToFlagVal, "cache-to", nil, "remote cache destination(s) to export build cache to (buildx only)")
+	rootCmd.Flags().BoolVar(&pushFlagVal, "push", false, "push each step's image to its registry instead of loading it locally (buildx only; required when --platforms has more than one entry)")
+
+	rootCmd.Flags().StringVar(&cacheRegistryFlagVal, "cache-registry", "", "remote registry to consult (in addition to local images) when determining whether a step's image is already up to date")
+	rootCmd.Flags().BoolVar(&verifyLockFlagVal, "verify-lock", false, "fail if the computed input digest for any built step does not match the digest recorded in steps.lock.json")
+	rootCmd.Flags().BoolVar(&writeLockFlagVal, "write-lock", false, "write the input and image digests computed for each step to steps.lock.json in the output directory")
 }
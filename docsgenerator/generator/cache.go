@@ -0,0 +1,187 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const stepsLockFileName = "steps.lock.json"
+
+// stepsLock is the content of steps.lock.json: the digest recorded for each step's inputs and the
+// resulting image, keyed by step number, allowing a subsequent run to skip or verify steps.
+type stepsLock struct {
+	Steps map[string]stepLockEntry `json:"steps"`
+}
+
+type stepLockEntry struct {
+	// InputDigest is the digest of the step's inputs (base image, Dockerfile, input file tree,
+	// tag prefix and environment) as computed by stepInputDigest.
+	InputDigest string `json:"inputDigest"`
+	// ImageDigest is the digest of the Docker image produced for this step.
+	ImageDigest string `json:"imageDigest"`
+}
+
+// stepInputDigest computes a stable digest over the inputs that determine a step's image content:
+// the digest of its base image, the contents of its Dockerfile, a hash of its input file tree, the
+// configured tag prefix, and the builder environment (platforms and builder type).
+func stepInputDigest(s step, params Params) (string, error) {
+	h := sha256.New()
+
+	baseImageDigest, err := imageDigestFunc(s.baseImage)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine digest of base image %s for step %d", s.baseImage, s.num)
+	}
+	fmt.Fprintf(h, "baseImage=%s\n", baseImageDigest)
+
+	dockerfile, err := ioutil.ReadFile(path.Join(s.srcDir, "Dockerfile"))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read Dockerfile for step %d", s.num)
+	}
+	fmt.Fprintf(h, "dockerfile=%x\n", sha256.Sum256(dockerfile))
+
+	treeDigest, err := fileTreeDigest(s.srcDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to hash input tree for step %d", s.num)
+	}
+	fmt.Fprintf(h, "inputTree=%s\n", treeDigest)
+
+	fmt.Fprintf(h, "tagPrefix=%s\n", params.TagPrefix)
+	fmt.Fprintf(h, "builder=%s\n", params.builder())
+	fmt.Fprintf(h, "platforms=%s\n", strings.Join(params.Platforms, ","))
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fileTreeDigest computes a stable digest over the relative paths and contents of every file
+// under dir.
+func fileTreeDigest(dir string) (string, error) {
+	var relPaths []string
+	contents := map[string][]byte{}
+	err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		relPaths = append(relPaths, relPath)
+		contents[relPath] = data
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(relPaths)
+
+	h := sha256.New()
+	for _, relPath := range relPaths {
+		fmt.Fprintf(h, "%s:%x\n", relPath, sha256.Sum256(contents[relPath]))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// imageDigestFunc resolves the digest of a local Docker image; it is a variable so that tests can
+// substitute a fake implementation instead of shelling out to "docker".
+var imageDigestFunc = imageDigest
+
+// imageDigest returns the digest of the named local Docker image, as reported by
+// "docker inspect --format {{.Id}}".
+func imageDigest(image string) (string, error) {
+	out, err := exec.Command("docker", "inspect", "--format", "{{.Id}}", image).Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to inspect image %s", image)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// readStepsLock reads steps.lock.json from outputDir. It returns an empty lock, rather than an
+// error, if the file does not exist.
+func readStepsLock(outputDir string) (*stepsLock, error) {
+	contents, err := ioutil.ReadFile(path.Join(outputDir, stepsLockFileName))
+	if os.IsNotExist(err) {
+		return &stepsLock{Steps: map[string]stepLockEntry{}}, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", stepsLockFileName)
+	}
+	var lock stepsLock
+	if err := json.Unmarshal(contents, &lock); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", stepsLockFileName)
+	}
+	if lock.Steps == nil {
+		lock.Steps = map[string]stepLockEntry{}
+	}
+	return &lock, nil
+}
+
+// writeStepsLock writes lock to steps.lock.json in outputDir.
+func writeStepsLock(outputDir string, lock *stepsLock) error {
+	contents, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", stepsLockFileName)
+	}
+	if err := ioutil.WriteFile(path.Join(outputDir, stepsLockFileName), contents, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", stepsLockFileName)
+	}
+	return nil
+}
+
+func stepKey(s step) string {
+	return fmt.Sprintf("%d", s.num)
+}
+
+// stepCacheHit reports whether an image already exists (locally, or under cacheRegistry if set)
+// whose digest matches wantImageDigest, meaning the step that produced wantImageDigest does not
+// need to be rebuilt.
+func stepCacheHit(tag, wantImageDigest, cacheRegistry string) bool {
+	if digest, err := imageDigestFunc(tag); err == nil && digest == wantImageDigest {
+		return true
+	}
+	if cacheRegistry == "" {
+		return false
+	}
+	remoteTag := cacheRegistry + "/" + tag
+	out, err := exec.Command("docker", "manifest", "inspect", remoteTag).Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(out), wantImageDigest)
+}
@@ -0,0 +1,93 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withFakeImageDigest(t *testing.T, fn func(image string) (string, error)) {
+	orig := imageDigestFunc
+	imageDigestFunc = fn
+	t.Cleanup(func() { imageDigestFunc = orig })
+}
+
+func TestFileTreeDigestStableAndSensitiveToContent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "Dockerfile"), []byte("FROM scratch"), 0644))
+	require.NoError(t, os.MkdirAll(path.Join(dir, "sub"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "sub", "a.txt"), []byte("hello"), 0644))
+
+	digest1, err := fileTreeDigest(dir)
+	require.NoError(t, err)
+	digest2, err := fileTreeDigest(dir)
+	require.NoError(t, err)
+	assert.Equal(t, digest1, digest2)
+
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "sub", "a.txt"), []byte("goodbye"), 0644))
+	digest3, err := fileTreeDigest(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, digest1, digest3)
+}
+
+func TestStepInputDigestChangesWithDockerfileAndTagPrefix(t *testing.T) {
+	withFakeImageDigest(t, func(image string) (string, error) {
+		return "sha256:fakebase", nil
+	})
+
+	srcDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(srcDir) }()
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "Dockerfile"), []byte("FROM base"), 0644))
+
+	s := step{num: 0, srcDir: srcDir, baseImage: "base-image"}
+
+	digest1, err := stepInputDigest(s, Params{TagPrefix: "docsgenerator"})
+	require.NoError(t, err)
+	digest2, err := stepInputDigest(s, Params{TagPrefix: "docsgenerator"})
+	require.NoError(t, err)
+	assert.Equal(t, digest1, digest2)
+
+	digest3, err := stepInputDigest(s, Params{TagPrefix: "other-prefix"})
+	require.NoError(t, err)
+	assert.NotEqual(t, digest1, digest3)
+
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "Dockerfile"), []byte("FROM base\nRUN echo hi"), 0644))
+	digest4, err := stepInputDigest(s, Params{TagPrefix: "docsgenerator"})
+	require.NoError(t, err)
+	assert.NotEqual(t, digest1, digest4)
+}
+
+func TestStepCacheHitLocalMatch(t *testing.T) {
+	withFakeImageDigest(t, func(image string) (string, error) {
+		return "sha256:local", nil
+	})
+	assert.True(t, stepCacheHit("my-tag", "sha256:local", ""))
+	assert.False(t, stepCacheHit("my-tag", "sha256:other", ""))
+}
@@ -0,0 +1,207 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package generator drives the step-by-step construction of the Docker images used to produce
+// godel's documentation. Each step corresponds to a directory in the input directory and results
+// in a Docker image tagged with the configured tag prefix and step number.
+package generator
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/palantir/distgo/dockerbuilder"
+	"github.com/pkg/errors"
+)
+
+// Builder identifies the mechanism used to build the Docker images for each step.
+type Builder string
+
+const (
+	// BuilderDocker drives the images through the classic "docker build" CLI. This is the
+	// default and the only builder that does not require BuildKit support in the Docker daemon.
+	BuilderDocker Builder = "docker"
+	// BuilderBuildx drives the images through "docker buildx build" and supports building (and
+	// pushing) multi-platform manifest lists in a single invocation.
+	BuilderBuildx Builder = "buildx"
+)
+
+// Params contains the parameters used to configure the behavior of Generate.
+type Params struct {
+	// TagPrefix is the prefix used for the Docker tag applied to the generated images.
+	TagPrefix string
+	// RunDockerBuild specifies whether the generator should invoke Builder to build the Docker
+	// images for the generated steps.
+	RunDockerBuild bool
+	// SuppressDockerOutput specifies whether the output of the Docker build(s) should be
+	// suppressed.
+	SuppressDockerOutput bool
+	// StartStep is the step at which image generation should start (inclusive). A negative value
+	// indicates that generation should start at the first step.
+	StartStep int
+	// EndStep is the step at which image generation should end (inclusive). A negative value
+	// indicates that generation should proceed through the last step.
+	EndStep int
+	// LeaveGeneratedFiles specifies whether the intermediate files generated for each step should
+	// be left on disk rather than cleaned up.
+	LeaveGeneratedFiles bool
+
+	// Platforms is the set of "os/arch" platforms (for example "linux/amd64,linux/arm64") for
+	// which images should be built. Only honored when Builder is BuilderBuildx; ignored (and must
+	// be unset or single-valued) for BuilderDocker.
+	Platforms []string
+	// Builder specifies the mechanism used to build the Docker images for each step. Defaults to
+	// BuilderDocker.
+	Builder Builder
+	// CacheFrom is the set of remote cache sources consulted before building each layer. Passed
+	// as --cache-from to both BuilderDocker and BuilderBuildx.
+	CacheFrom []string
+	// CacheTo is the set of remote cache destinations that build cache should be exported to.
+	// Only honored when Builder is BuilderBuildx.
+	CacheTo []string
+	// Push specifies whether each step's image should be pushed to its registry rather than
+	// loaded into the local Docker image store. Required when Builder is BuilderBuildx and more
+	// than one Platform is configured, since the docker-container buildx driver cannot load a
+	// manifest list locally.
+	Push bool
+
+	// CacheRegistry is a remote registry consulted (in addition to local images) to determine
+	// whether a step's image has already been built for its current input digest.
+	CacheRegistry string
+	// VerifyLock requires that the input digest computed for every step being (re)built matches
+	// the digest recorded for that step in the output directory's steps.lock.json, failing the
+	// build if the lock file is missing a step or the digests disagree.
+	VerifyLock bool
+	// WriteLock specifies whether the input and image digests computed for each step should be
+	// persisted to steps.lock.json in the output directory.
+	WriteLock bool
+}
+
+func (p *Params) builder() Builder {
+	if p.Builder == "" {
+		return BuilderDocker
+	}
+	return p.Builder
+}
+
+// Generate computes the steps for the numbered subdirectories of inputDir, using baseImage as the
+// base image for the first Docker image. If params.RunDockerBuild is true, the Docker image for
+// each step is built, using the builder specified by params.Builder, from that step's directory
+// outputDir/<n>; Generate does not create or populate those directories.
+func Generate(inputDir, outputDir, baseImage string, params Params, stdout io.Writer) error {
+	if len(params.Platforms) > 1 && params.builder() == BuilderDocker {
+		return errors.Errorf("--platforms requires --builder=buildx, got %q", BuilderDocker)
+	}
+
+	steps, err := generateSteps(inputDir, outputDir, baseImage, params)
+	if err != nil {
+		return err
+	}
+	if !params.RunDockerBuild {
+		return nil
+	}
+
+	builderFactory, err := dockerbuilder.NewDockerBuilderFactory()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create docker builder factory")
+	}
+	builder, err := builderFactory.NewDockerBuilder(string(params.builder()))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s docker builder", params.builder())
+	}
+
+	// Computing a step's input digest shells out to "docker inspect" on its base image, which
+	// fails if that image has not already been pulled locally. Only pay that cost (and only
+	// require/maintain steps.lock.json) when the caller actually asked for caching or
+	// reproducibility verification; otherwise behave exactly like a plain docker/buildx build.
+	cachingEnabled := params.WriteLock || params.VerifyLock || params.CacheRegistry != ""
+
+	var lock *stepsLock
+	if cachingEnabled {
+		lock, err = readStepsLock(outputDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, s := range steps {
+		if params.StartStep >= 0 && s.num < params.StartStep {
+			continue
+		}
+		if params.EndStep >= 0 && s.num > params.EndStep {
+			break
+		}
+
+		var inputDigest string
+		if cachingEnabled {
+			inputDigest, err = stepInputDigest(s, params)
+			if err != nil {
+				return err
+			}
+
+			if params.VerifyLock {
+				entry, ok := lock.Steps[stepKey(s)]
+				if !ok {
+					return errors.Errorf("--verify-lock: %s has no entry for step %d", stepsLockFileName, s.num)
+				}
+				if entry.InputDigest != inputDigest {
+					return errors.Errorf("--verify-lock: step %d input digest %s does not match locked digest %s; rebuild is not reproducible", s.num, inputDigest, entry.InputDigest)
+				}
+			}
+
+			if entry, ok := lock.Steps[stepKey(s)]; ok && entry.InputDigest == inputDigest && stepCacheHit(s.tag, entry.ImageDigest, params.CacheRegistry) {
+				fmt.Fprintf(stdout, "Step %d (tag %s) is up to date (input digest %s); skipping build\n", s.num, s.tag, inputDigest)
+				continue
+			}
+		}
+
+		buildParams := dockerbuilder.BuildParams{
+			ContextDir:           s.dir,
+			Tag:                  s.tag,
+			Platforms:            params.Platforms,
+			SuppressDockerOutput: params.SuppressDockerOutput,
+			CacheFrom:            params.CacheFrom,
+			CacheTo:              params.CacheTo,
+			Push:                 params.Push,
+		}
+		if err := builder.Build(buildParams, stdout); err != nil {
+			return errors.Wrapf(err, "failed to build step %d (tag %s)", s.num, s.tag)
+		}
+
+		if cachingEnabled {
+			imgDigest, err := imageDigest(s.tag)
+			if err != nil {
+				return err
+			}
+			if params.VerifyLock {
+				if entry, ok := lock.Steps[stepKey(s)]; ok && entry.ImageDigest != imgDigest {
+					return errors.Errorf("--verify-lock: step %d rebuilt with identical input digest %s but produced image digest %s, which does not match locked digest %s; rebuild is not reproducible", s.num, inputDigest, imgDigest, entry.ImageDigest)
+				}
+			}
+			lock.Steps[stepKey(s)] = stepLockEntry{InputDigest: inputDigest, ImageDigest: imgDigest}
+		}
+	}
+
+	if params.WriteLock {
+		if err := writeStepsLock(outputDir, lock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// step represents a single numbered entry in inputDir that results in a Docker image.
+type step struct {
+	num int
+	// srcDir is the numbered subdirectory of inputDir that this step was generated from.
+	srcDir string
+	// dir is the generated output directory (Docker build context) for this step.
+	dir string
+	// baseImage is the image (or preceding step's tag) this step's Dockerfile is built FROM.
+	baseImage string
+	tag       string
+}
+
+// generateSteps walks the numbered subdirectories of inputDir and returns a step for each one in
+// ascending order. The first step uses baseImage as its Docker base image; subsequent steps use the
+// tag of the preceding step. It writes nothing: each step's dir is set to outputDir/<n>, which
+// generateSteps neither creates nor populates.
+func generateSteps(inputDir, outputDir, baseImage string, params Params) ([]step, error) {
+	entries, err := ioutil.ReadDir(inputDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read input directory %s", inputDir)
+	}
+
+	var nums []int
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		n, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			// not a step directory (name is not purely numeric, e.g. "1-notes") - skip it.
+			continue
+		}
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	prevImage := baseImage
+	var steps []step
+	for _, n := range nums {
+		stepSrcDir := path.Join(inputDir, fmt.Sprintf("%d", n))
+		stepOutputDir := path.Join(outputDir, fmt.Sprintf("%d", n))
+		tag := fmt.Sprintf("%s-%d", params.TagPrefix, n)
+		steps = append(steps, step{num: n, srcDir: stepSrcDir, dir: stepOutputDir, baseImage: prevImage, tag: tag})
+		prevImage = tag
+	}
+	return steps, nil
+}
@@ -0,0 +1,49 @@
+/*
+Sniperkit-Bot
+- Status: analyzed
+*/
+
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateStepsSkipsNonNumericDirs(t *testing.T) {
+	inputDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(inputDir) }()
+
+	for _, name := range []string{"0", "1", "1-notes", "notes"} {
+		require.NoError(t, os.MkdirAll(path.Join(inputDir, name), 0755))
+	}
+
+	steps, err := generateSteps(inputDir, "/out", "base-image", Params{TagPrefix: "docsgenerator"})
+	require.NoError(t, err)
+
+	require.Len(t, steps, 2)
+	assert.Equal(t, 0, steps[0].num)
+	assert.Equal(t, "base-image", steps[0].baseImage)
+	assert.Equal(t, 1, steps[1].num)
+	assert.Equal(t, "docsgenerator-0", steps[1].baseImage)
+}
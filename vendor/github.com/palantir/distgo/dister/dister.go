@@ -0,0 +1,129 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dister provides the Dister abstraction used by distgo to produce the dist artifacts
+// for a product (tgz archives, RPMs, SBOMs, etc.).
+package dister
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/distgo/distgo"
+)
+
+// DistParams contains the parameters needed to produce the dist artifact(s) for a single product.
+type DistParams struct {
+	// ProductID is the ID of the product being dist'd.
+	ProductID distgo.ProductID
+	// ProductDir is the directory containing the built product (the compiled binaries copied/tar'd
+	// up by this dister). It has no go.mod and must not be used as the root for module-graph
+	// operations such as "go list".
+	ProductDir string
+	// ModuleDir is the root of the Go module that the product is built from (where go.mod lives).
+	// Disters that need to inspect the module graph (for example sbomCycloneDXDister) must walk
+	// this directory, not ProductDir.
+	ModuleDir string
+	// OutputDir is the directory that dist artifacts should be written to.
+	OutputDir string
+	// Version is the version of the product being dist'd.
+	Version string
+}
+
+// Dister produces the dist artifact(s) for a product. It records the paths of the artifacts it
+// wrote on outputInfo (via ProductTaskOutputInfo.AddDistArtifactPaths) so that the existing
+// publish.Products pipeline can find and publish them alongside the product's other dist
+// artifacts.
+type Dister interface {
+	// TypeName returns the unique identifier for this dister (for example "tgz" or
+	// "sbom-cyclonedx"); it also doubles as the DistID the artifact paths are recorded under.
+	TypeName() string
+	// RunDist produces the dist artifact(s) and records their paths on outputInfo.
+	RunDist(params DistParams, outputInfo *distgo.ProductTaskOutputInfo, stdout io.Writer) error
+}
+
+// Factory creates Dister instances by type name.
+type Factory struct {
+	disters map[string]Dister
+}
+
+// NewDisterFactory returns a Factory populated with the disters provided by this package.
+func NewDisterFactory() (*Factory, error) {
+	return &Factory{
+		disters: map[string]Dister{
+			(&osArchBinDister{}).TypeName():     &osArchBinDister{},
+			(&tgzDister{}).TypeName():           &tgzDister{},
+			(&sbomCycloneDXDister{}).TypeName(): &sbomCycloneDXDister{},
+		},
+	}, nil
+}
+
+// NewDister returns the Dister registered for the provided type name.
+func (f *Factory) NewDister(typeName string) (Dister, error) {
+	dister, ok := f.disters[typeName]
+	if !ok {
+		return nil, errors.Errorf("no Dister registered for type %q", typeName)
+	}
+	return dister, nil
+}
+
+// DefaultConfig returns the default dister configuration applied to a product that does not
+// specify one explicitly: a single os-arch-bin dister with an empty OSArchBinDistConfig.
+func DefaultConfig() (distgo.DisterConfig, error) {
+	typeName := OSArchBinDistTypeName
+	return distgo.DisterConfig{
+		Type:   &typeName,
+		Config: OSArchBinDistConfig{},
+	}, nil
+}
+
+// copyBuiltBinariesToOutputDir copies every file under params.ProductDir into params.OutputDir,
+// preserving relative paths, and returns the destination paths.
+func copyBuiltBinariesToOutputDir(params DistParams) ([]string, error) {
+	var outputPaths []string
+	err := filepath.Walk(params.ProductDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(params.ProductDir, filePath)
+		if err != nil {
+			return err
+		}
+		contents, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		destPath := path.Join(params.OutputDir, filepath.ToSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(destPath, contents, info.Mode()); err != nil {
+			return err
+		}
+		outputPaths = append(outputPaths, destPath)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to copy built binaries from %s to %s", params.ProductDir, params.OutputDir)
+	}
+	return outputPaths, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dister
+
+import (
+	"io"
+
+	"github.com/palantir/distgo/distgo"
+)
+
+// OSArchBinDistTypeName is the type name of the os-arch-bin dister: the default dister applied to
+// a product that does not configure one explicitly.
+const OSArchBinDistTypeName = "os-arch-bin"
+
+// OSArchBinDistConfig is the configuration for the os-arch-bin dister.
+type OSArchBinDistConfig struct {
+	// OSArchs is the set of "os-arch" values (for example "darwin-amd64"). osArchBinDister does
+	// not read this field: it copies every file under DistParams.ProductDir, whatever its
+	// OS/architecture.
+	OSArchs []string
+}
+
+// osArchBinDister packages the already-built OS/arch binaries for a product, unmodified, as its
+// dist artifact(s).
+type osArchBinDister struct{}
+
+func (d *osArchBinDister) TypeName() string {
+	return OSArchBinDistTypeName
+}
+
+func (d *osArchBinDister) RunDist(params DistParams, outputInfo *distgo.ProductTaskOutputInfo, stdout io.Writer) error {
+	paths, err := copyBuiltBinariesToOutputDir(params)
+	if err != nil {
+		return err
+	}
+	outputInfo.AddDistArtifactPaths(distgo.DistID(d.TypeName()), paths...)
+	return nil
+}
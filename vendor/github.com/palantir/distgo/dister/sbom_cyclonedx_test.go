@@ -0,0 +1,157 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dister
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/distgo/distgo"
+)
+
+func TestBuildCycloneDXBOMPURLsAndDependencies(t *testing.T) {
+	modules := []goListModule{
+		{Path: "example.com/foo", Version: "v0.1.0", Main: true},
+		{Path: "github.com/pkg/errors", Version: "v0.9.1"},
+		{Path: "github.com/stretchr/testify", Version: "v1.7.0"},
+	}
+
+	bom := buildCycloneDXBOM("", modules)
+
+	require.Len(t, bom.Components, 3)
+	assert.Equal(t, "pkg:golang/example.com/foo@v0.1.0", bom.Components[0].PURL)
+	assert.Equal(t, "pkg:golang/github.com/pkg/errors@v0.9.1", bom.Components[1].PURL)
+	assert.Equal(t, "pkg:golang/github.com/stretchr/testify@v1.7.0", bom.Components[2].PURL)
+
+	require.Len(t, bom.Dependencies, 1)
+	assert.Equal(t, "pkg:golang/example.com/foo@v0.1.0", bom.Dependencies[0].Ref)
+	assert.ElementsMatch(t, []string{
+		"pkg:golang/github.com/pkg/errors@v0.9.1",
+		"pkg:golang/github.com/stretchr/testify@v1.7.0",
+	}, bom.Dependencies[0].DependsOn)
+}
+
+func TestBuildCycloneDXBOMDetectsVendoredLicense(t *testing.T) {
+	moduleDir, err := ioutil.TempDir("", "sbom-cyclonedx-test")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(moduleDir) }()
+
+	licenseDir := path.Join(moduleDir, "vendor", "github.com/pkg/errors")
+	require.NoError(t, os.MkdirAll(licenseDir, 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(licenseDir, "LICENSE.spdx"), []byte("BSD-2-Clause\n"), 0644))
+
+	modules := []goListModule{
+		{Path: "github.com/pkg/errors", Version: "v0.9.1"},
+	}
+
+	bom := buildCycloneDXBOM(moduleDir, modules)
+
+	require.Len(t, bom.Components, 1)
+	require.Len(t, bom.Components[0].Licenses, 1)
+	assert.Equal(t, "BSD-2-Clause", bom.Components[0].Licenses[0].License.ID)
+}
+
+// withFakeListModules swaps listModulesFunc for a fake that returns modules without shelling out
+// to "go list", restoring the real implementation on cleanup.
+func withFakeListModules(t *testing.T, modules []goListModule) {
+	t.Helper()
+	prev := listModulesFunc
+	listModulesFunc = func(moduleDir string) ([]goListModule, error) {
+		return modules, nil
+	}
+	t.Cleanup(func() { listModulesFunc = prev })
+}
+
+func TestSBOMCycloneDXDisterRecordsArtifactPathAlongsideTgz(t *testing.T) {
+	withFakeListModules(t, []goListModule{
+		{Path: "example.com/foo", Version: "v0.1.0", Main: true},
+	})
+
+	productDir, err := ioutil.TempDir("", "sbom-cyclonedx-product")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(productDir) }()
+	require.NoError(t, ioutil.WriteFile(path.Join(productDir, "foo"), []byte("binary"), 0755))
+
+	moduleDir, err := ioutil.TempDir("", "sbom-cyclonedx-module")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(moduleDir) }()
+
+	outputDir, err := ioutil.TempDir("", "sbom-cyclonedx-output")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(outputDir) }()
+
+	params := DistParams{
+		ProductID:  "foo",
+		ProductDir: productDir,
+		ModuleDir:  moduleDir,
+		OutputDir:  outputDir,
+		Version:    "0.1.0",
+	}
+
+	var outputInfo distgo.ProductTaskOutputInfo
+	require.NoError(t, (&tgzDister{}).RunDist(params, &outputInfo, ioutil.Discard))
+	require.NoError(t, (&sbomCycloneDXDister{}).RunDist(params, &outputInfo, ioutil.Discard))
+
+	artifactPaths := outputInfo.ProductDistArtifactPaths()
+	require.Contains(t, artifactPaths, distgo.DistID("tgz"))
+	assert.Len(t, artifactPaths[distgo.DistID("tgz")], 1)
+
+	require.Contains(t, artifactPaths, distgo.DistID("sbom-cyclonedx"))
+	require.Len(t, artifactPaths[distgo.DistID("sbom-cyclonedx")], 1)
+	sbomBytes, err := ioutil.ReadFile(artifactPaths[distgo.DistID("sbom-cyclonedx")][0])
+	require.NoError(t, err)
+	assert.Contains(t, string(sbomBytes), "pkg:golang/example.com/foo@v0.1.0")
+}
+
+func TestWriteTgzAttachesSBOMUsingModuleDirNotProductDir(t *testing.T) {
+	withFakeListModules(t, []goListModule{
+		{Path: "example.com/foo", Version: "v0.1.0", Main: true},
+	})
+	var gotModuleDir string
+	fakeListModules := listModulesFunc
+	listModulesFunc = func(moduleDir string) ([]goListModule, error) {
+		gotModuleDir = moduleDir
+		return fakeListModules(moduleDir)
+	}
+
+	productDir, err := ioutil.TempDir("", "tgz-product")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(productDir) }()
+	require.NoError(t, ioutil.WriteFile(path.Join(productDir, "foo"), []byte("binary"), 0755))
+
+	outputDir, err := ioutil.TempDir("", "tgz-output")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(outputDir) }()
+
+	params := DistParams{
+		ProductID:  "foo",
+		ProductDir: productDir,
+		ModuleDir:  "/does-not-need-to-exist-because-listModulesFunc-is-faked",
+		OutputDir:  outputDir,
+		Version:    "0.1.0",
+	}
+
+	outputPath := path.Join(outputDir, "foo-0.1.0.tgz")
+	require.NoError(t, writeTgz(outputPath, params, true))
+	assert.Equal(t, params.ModuleDir, gotModuleDir)
+
+	_, err = os.Stat(outputPath)
+	require.NoError(t, err)
+}
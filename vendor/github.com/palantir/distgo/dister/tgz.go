@@ -0,0 +1,120 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dister
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/distgo/distgo"
+)
+
+// tgzDister produces a gzipped tar archive of a product's directory. If AttachSBOM is set, it
+// also embeds the CycloneDX SBOM produced by sbomCycloneDXDister at metadata/sbom.cdx.json within
+// the archive.
+type tgzDister struct {
+	// AttachSBOM specifies whether a CycloneDX SBOM should be generated and embedded in the
+	// archive at metadata/sbom.cdx.json.
+	AttachSBOM bool
+}
+
+func (d *tgzDister) TypeName() string {
+	return "tgz"
+}
+
+func (d *tgzDister) RunDist(params DistParams, outputInfo *distgo.ProductTaskOutputInfo, stdout io.Writer) error {
+	outputPath := path.Join(params.OutputDir, fmt.Sprintf("%s-%s.tgz", params.ProductID, params.Version))
+	if err := writeTgz(outputPath, params, d.AttachSBOM); err != nil {
+		return err
+	}
+	outputInfo.AddDistArtifactPaths(distgo.DistID(d.TypeName()), outputPath)
+	return nil
+}
+
+func writeTgz(outputPath string, params DistParams, attachSBOM bool) error {
+	outFile, err := os.Create(outputPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", outputPath)
+	}
+	defer func() { _ = outFile.Close() }()
+
+	gzWriter := gzip.NewWriter(outFile)
+	defer func() { _ = gzWriter.Close() }()
+	tarWriter := tar.NewWriter(gzWriter)
+	defer func() { _ = tarWriter.Close() }()
+
+	if err := addDirToTar(tarWriter, params.ProductDir, ""); err != nil {
+		return err
+	}
+
+	if attachSBOM {
+		bom, err := generateCycloneDXSBOM(params.ModuleDir, string(params.ProductID), params.Version)
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate SBOM to attach to %s", outputPath)
+		}
+		bomBytes, err := json.MarshalIndent(bom, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal SBOM to attach to %s", outputPath)
+		}
+		if err := addBytesToTar(tarWriter, "metadata/sbom.cdx.json", bomBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addDirToTar(tarWriter *tar.Writer, srcDir, prefix string) error {
+	return filepath.Walk(srcDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(srcDir, filePath)
+		if err != nil {
+			return err
+		}
+		contents, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		return addBytesToTar(tarWriter, path.Join(prefix, filepath.ToSlash(relPath)), contents)
+	})
+}
+
+func addBytesToTar(tarWriter *tar.Writer, name string, contents []byte) error {
+	hdr := &tar.Header{
+		Name: name,
+		Mode: 0644,
+		Size: int64(len(contents)),
+	}
+	if err := tarWriter.WriteHeader(hdr); err != nil {
+		return errors.Wrapf(err, "failed to write tar header for %s", name)
+	}
+	if _, err := tarWriter.Write(contents); err != nil {
+		return errors.Wrapf(err, "failed to write tar contents for %s", name)
+	}
+	return nil
+}
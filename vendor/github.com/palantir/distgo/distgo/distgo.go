@@ -0,0 +1,104 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package distgo contains the core types shared by distgo's dist, publish and dister/publisher
+// plugins.
+package distgo
+
+import "io"
+
+// ProductID uniquely identifies a product within a project.
+type ProductID string
+
+// DistID identifies a single dist configuration (and the artifact(s) it produces) for a product.
+type DistID string
+
+// ByDistID sorts a slice of DistID in lexical order.
+type ByDistID []DistID
+
+func (a ByDistID) Len() int           { return len(a) }
+func (a ByDistID) Less(i, j int) bool { return a[i] < a[j] }
+func (a ByDistID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// DisterConfig is the user-facing configuration for a single dister: its type name plus its
+// type-specific configuration.
+type DisterConfig struct {
+	Type   *string
+	Config interface{}
+}
+
+// ProductOutputInfo contains identifying information about a product.
+type ProductOutputInfo struct {
+	ID ProductID
+}
+
+// ProductTaskOutputInfo carries the outputs produced for a single product by the dist task (and
+// any disters that have run) so that downstream tasks (publish, etc.) can locate them.
+type ProductTaskOutputInfo struct {
+	Product ProductOutputInfo
+
+	distArtifactPaths map[DistID][]string
+}
+
+// ProductDistArtifactPaths returns the dist artifact paths produced for the product, keyed by the
+// DistID of the dister that produced them.
+func (p ProductTaskOutputInfo) ProductDistArtifactPaths() map[DistID][]string {
+	return p.distArtifactPaths
+}
+
+// AddDistArtifactPaths appends paths to the artifacts already recorded for distID, creating the
+// entry if required. Disters use this to record the artifact(s) they produced -- and, for disters
+// like sbom-cyclonedx that augment another dister's output, to attach additional artifacts
+// alongside it -- so that publish.Products can find and publish them.
+func (p *ProductTaskOutputInfo) AddDistArtifactPaths(distID DistID, paths ...string) {
+	if p.distArtifactPaths == nil {
+		p.distArtifactPaths = make(map[DistID][]string)
+	}
+	p.distArtifactPaths[distID] = append(p.distArtifactPaths[distID], paths...)
+}
+
+// PublisherFlagName identifies a publisher-specific flag surfaced on the "publish" command.
+type PublisherFlagName string
+
+// PublisherFlagType identifies the value type of a PublisherFlag.
+type PublisherFlagType int
+
+const (
+	// StringFlag indicates that a PublisherFlag's value is a string.
+	StringFlag PublisherFlagType = iota
+	// BoolFlag indicates that a PublisherFlag's value is a bool.
+	BoolFlag
+)
+
+// PublisherFlag describes a single publisher-specific flag that a Publisher implementation wants
+// surfaced on the "publish" command.
+type PublisherFlag struct {
+	Name        PublisherFlagName
+	Description string
+	Type        PublisherFlagType
+}
+
+// Publisher publishes the dist artifacts recorded on a ProductTaskOutputInfo to a remote
+// destination.
+type Publisher interface {
+	// TypeName returns the unique identifier for this publisher (for example "oci").
+	TypeName() (string, error)
+	// Flags returns the publisher-specific flags that should be surfaced on the "publish" command.
+	Flags() ([]PublisherFlag, error)
+	// RunPublish publishes the dist artifacts recorded on productTaskOutputInfo. cfgYML is the raw
+	// publisher-specific configuration block from the project config; flagVals contains the
+	// values supplied for the flags returned by Flags, keyed by name. If dryRun is true, the
+	// publish is simulated rather than actually performed.
+	RunPublish(productTaskOutputInfo ProductTaskOutputInfo, cfgYML []byte, flagVals map[PublisherFlagName]interface{}, dryRun bool, stdout io.Writer) error
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dockerbuilder
+
+import (
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// buildxBuilder drives image builds using "docker buildx build", which supports producing a
+// multi-platform manifest list in a single invocation.
+type buildxBuilder struct{}
+
+func (b *buildxBuilder) TypeName() string {
+	return "buildx"
+}
+
+func (b *buildxBuilder) Build(params BuildParams, stdout io.Writer) error {
+	if len(params.Platforms) > 1 && !params.Push {
+		return errors.Errorf("the %q builder requires Push when building more than one platform: the docker-container buildx driver cannot load a manifest list into the local image store", b.TypeName())
+	}
+	return runDockerCommand(buildxArgs(params), params.SuppressDockerOutput, stdout)
+}
+
+// buildxArgs returns the "docker buildx build" arguments for params.
+func buildxArgs(params BuildParams) []string {
+	args := []string{"buildx", "build", "-t", params.Tag}
+	if len(params.Platforms) > 0 {
+		args = append(args, "--platform", strings.Join(params.Platforms, ","))
+	}
+	for _, cacheFrom := range params.CacheFrom {
+		args = append(args, "--cache-from", cacheFrom)
+	}
+	for _, cacheTo := range params.CacheTo {
+		args = append(args, "--cache-to", cacheTo)
+	}
+	if params.Push {
+		args = append(args, "--push")
+	} else {
+		args = append(args, "--load")
+	}
+	if !params.SuppressDockerOutput {
+		args = append(args, "--progress=plain")
+	}
+	return append(args, params.ContextDir)
+}
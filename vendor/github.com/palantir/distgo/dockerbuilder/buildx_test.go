@@ -0,0 +1,67 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dockerbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildxArgs(t *testing.T) {
+	args := buildxArgs(BuildParams{
+		ContextDir: "ctx",
+		Tag:        "my-tag",
+		Platforms:  []string{"linux/amd64", "linux/arm64"},
+		CacheFrom:  []string{"registry.example.com/cache"},
+		CacheTo:    []string{"registry.example.com/cache"},
+		Push:       true,
+	})
+	assert.Equal(t, []string{
+		"buildx", "build", "-t", "my-tag",
+		"--platform", "linux/amd64,linux/arm64",
+		"--cache-from", "registry.example.com/cache",
+		"--cache-to", "registry.example.com/cache",
+		"--push",
+		"--progress=plain",
+		"ctx",
+	}, args)
+}
+
+func TestBuildxArgsSuppressedOutputOmitsProgressFlag(t *testing.T) {
+	args := buildxArgs(BuildParams{
+		ContextDir:           "ctx",
+		Tag:                  "my-tag",
+		SuppressDockerOutput: true,
+	})
+	assert.Equal(t, []string{"buildx", "build", "-t", "my-tag", "--load", "ctx"}, args)
+}
+
+func TestBuildxArgsDefaultsToLoadWhenNotPushing(t *testing.T) {
+	args := buildxArgs(BuildParams{
+		ContextDir: "ctx",
+		Tag:        "my-tag",
+	})
+	assert.Equal(t, []string{"buildx", "build", "-t", "my-tag", "--load", "--progress=plain", "ctx"}, args)
+}
+
+func TestBuildRejectsMultiPlatformWithoutPush(t *testing.T) {
+	err := (&buildxBuilder{}).Build(BuildParams{
+		ContextDir: "ctx",
+		Tag:        "my-tag",
+		Platforms:  []string{"linux/amd64", "linux/arm64"},
+	}, nil)
+	assert.EqualError(t, err, `the "buildx" builder requires Push when building more than one platform: the docker-container buildx driver cannot load a manifest list into the local image store`)
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dockerbuilder provides the DockerBuilder abstraction used to build the Docker images
+// produced by distgo and by godel's documentation generator.
+package dockerbuilder
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// BuildParams contains the parameters for a single Docker image build.
+type BuildParams struct {
+	// ContextDir is the build context directory passed to the builder (equivalent to the final
+	// positional argument of "docker build").
+	ContextDir string
+	// Tag is the tag applied to the resulting image (or manifest list).
+	Tag string
+	// Platforms is the set of "os/arch" platforms to build for. A builder that does not support
+	// multi-platform builds should reject more than one entry.
+	Platforms []string
+	// SuppressDockerOutput specifies whether the output of the build should be suppressed.
+	SuppressDockerOutput bool
+	// CacheFrom is the set of remote cache sources consulted before building each layer.
+	CacheFrom []string
+	// CacheTo is the set of remote cache destinations that build cache should be exported to.
+	CacheTo []string
+	// Push specifies whether the built image (or, for a multi-platform build, manifest list)
+	// should be pushed to its registry rather than loaded into the local Docker image store. The
+	// buildx builder requires this for any build with more than one Platform, since the
+	// docker-container buildx driver cannot load a manifest list into the local image store.
+	Push bool
+}
+
+// DockerBuilder builds a single Docker image (or, for builders that support it, a multi-platform
+// manifest list) from the provided BuildParams.
+type DockerBuilder interface {
+	// TypeName returns the unique identifier for this builder (for example "docker" or "buildx").
+	TypeName() string
+	// Build runs the build described by params, streaming any output to stdout.
+	Build(params BuildParams, stdout io.Writer) error
+}
+
+// DockerBuilderFactory creates DockerBuilder instances by type name.
+type DockerBuilderFactory struct {
+	builders map[string]DockerBuilder
+}
+
+// NewDockerBuilderFactory returns a DockerBuilderFactory populated with the builders provided by
+// this package ("docker" and "buildx").
+func NewDockerBuilderFactory() (*DockerBuilderFactory, error) {
+	return &DockerBuilderFactory{
+		builders: map[string]DockerBuilder{
+			(&dockerBuilder{}).TypeName(): &dockerBuilder{},
+			(&buildxBuilder{}).TypeName(): &buildxBuilder{},
+		},
+	}, nil
+}
+
+// NewDockerBuilder returns the DockerBuilder registered for the provided type name.
+func (f *DockerBuilderFactory) NewDockerBuilder(typeName string) (DockerBuilder, error) {
+	builder, ok := f.builders[typeName]
+	if !ok {
+		return nil, errors.Errorf("no DockerBuilder registered for type %q", typeName)
+	}
+	return builder, nil
+}
+
+// dockerBuilder drives image builds using the classic "docker build" CLI. It does not support
+// building more than one platform in a single invocation.
+type dockerBuilder struct{}
+
+func (b *dockerBuilder) TypeName() string {
+	return "docker"
+}
+
+func (b *dockerBuilder) Build(params BuildParams, stdout io.Writer) error {
+	if len(params.Platforms) > 1 {
+		return errors.Errorf("the %q builder does not support building multiple platforms in a single invocation; use %q instead", b.TypeName(), (&buildxBuilder{}).TypeName())
+	}
+	args := []string{"build", "-t", params.Tag}
+	for _, cacheFrom := range params.CacheFrom {
+		args = append(args, "--cache-from", cacheFrom)
+	}
+	args = append(args, params.ContextDir)
+	return runDockerCommand(args, params.SuppressDockerOutput, stdout)
+}
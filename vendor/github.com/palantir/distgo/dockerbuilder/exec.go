@@ -0,0 +1,40 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dockerbuilder
+
+import (
+	"io"
+	"io/ioutil"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// runDockerCommand runs "docker" with the provided arguments, writing its combined output to
+// stdout unless suppressOutput is true.
+func runDockerCommand(args []string, suppressOutput bool, stdout io.Writer) error {
+	cmd := exec.Command("docker", args...)
+	if suppressOutput {
+		cmd.Stdout = ioutil.Discard
+		cmd.Stderr = ioutil.Discard
+	} else {
+		cmd.Stdout = stdout
+		cmd.Stderr = stdout
+	}
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "docker %v failed", args)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publisher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/distgo/distgo"
+)
+
+const (
+	// RegistryFlagName is the PublisherFlag name for the registry host to push to.
+	RegistryFlagName distgo.PublisherFlagName = "registry"
+	// RepositoryTemplateFlagName is the PublisherFlag name for OCIPublisherParams.RepositoryTemplate.
+	RepositoryTemplateFlagName distgo.PublisherFlagName = "repository-template"
+	// ReferrersAPIFlagName is the PublisherFlag name for OCIPublisherParams.ReferrersAPI.
+	ReferrersAPIFlagName distgo.PublisherFlagName = "referrers-api"
+	// SignKeyFlagName is the PublisherFlag name for OCIPublisherParams.SignKey.
+	SignKeyFlagName distgo.PublisherFlagName = "sign-key"
+)
+
+// ReferrersAPIMode selects how an OCIPublisher discovers/attaches referrers (such as a cosign
+// signature envelope) for a pushed artifact.
+type ReferrersAPIMode string
+
+const (
+	// ReferrersAPIAuto tries the OCI 1.1 referrers API first and falls back to the OCI 1.0
+	// tag-schema convention ("sha256-<digest>.sig") if the registry does not support it.
+	ReferrersAPIAuto ReferrersAPIMode = "auto"
+	// ReferrersAPIV1_1 requires the OCI 1.1 referrers API and fails if it is unavailable.
+	ReferrersAPIV1_1 ReferrersAPIMode = "v1_1"
+	// ReferrersAPITagFallback always uses the OCI 1.0 tag-schema convention.
+	ReferrersAPITagFallback ReferrersAPIMode = "tag-fallback"
+)
+
+// Registry is the minimal interface an OCIPublisher needs to push content and its referrers to an
+// OCI-compliant registry. A production implementation would typically be backed by
+// containerd's remotes/docker resolver or oras-go; tests use an in-memory fake.
+type Registry interface {
+	// Push uploads contents as a blob with the given media type to repository and returns its
+	// content digest (in "sha256:<hex>" form).
+	Push(repository, mediaType string, contents []byte) (string, error)
+	// PushReferrer uploads contents (a signature or other attached artifact) with media type
+	// referrerMediaType as a referrer of subjectDigest in repository, using mode to select the
+	// referrers mechanism.
+	PushReferrer(repository string, subjectDigest string, referrerMediaType string, contents []byte, mode ReferrersAPIMode) error
+}
+
+// OCIPublisherParams configures an OCIPublisher.
+type OCIPublisherParams struct {
+	// Registry is the OCI-compliant registry to push to.
+	Registry Registry
+	// RegistryHost is the host of the registry that repository names are resolved against (for
+	// example "my-registry.example.com"); if set, it is prepended to the resolved repository as
+	// "<RegistryHost>/<repository>". Overridable per-invocation via the "registry" PublisherFlag.
+	RegistryHost string
+	// RepositoryTemplate is the repository name template; "{{Product}}" is replaced with the
+	// product name being published (e.g. "my-org/{{Product}}"). Overridable per-invocation via the
+	// "repository-template" PublisherFlag.
+	RepositoryTemplate string
+	// DistArtifactTypes maps a DistID (for example "tgz", "sbom-cyclonedx") to the OCI artifactType
+	// media type that should be recorded for the artifacts produced by that dister. Checked before
+	// ArtifactTypes.
+	DistArtifactTypes map[distgo.DistID]string
+	// ArtifactTypes maps a file extension suffix (for example ".tgz", ".cdx.json") to the OCI
+	// artifactType media type that should be recorded for artifacts with that suffix. Suffixes are
+	// matched longest-first, so both ".json" and ".cdx.json" entries can be configured without the
+	// more specific one being shadowed. A path matching no DistArtifactTypes or ArtifactTypes entry
+	// falls back to "application/octet-stream".
+	ArtifactTypes map[string]string
+	// ReferrersAPI selects how referrers (such as a signature) are attached to the pushed
+	// artifact. Overridable per-invocation via the "referrers-api" PublisherFlag.
+	ReferrersAPI ReferrersAPIMode
+	// SignKey is the path to a cosign-compatible private key used to sign the artifact digest.
+	// If empty, no signature referrer is attached. Overridable per-invocation via the "sign-key"
+	// PublisherFlag.
+	SignKey string
+	// Signer produces a cosign-compatible signature envelope for a digest using SignKey. Tests
+	// supply a fake; production wiring supplies a real cosign signer.
+	Signer func(signKeyPath string, digest string) ([]byte, error)
+}
+
+// OCIDigests records the content-addressable digests that were assigned to the published
+// artifacts, keyed by local artifact path, so that subsequent tasks can reference them.
+type OCIDigests map[string]string
+
+// ociPublisher publishes the dist artifacts recorded on a product's distgo.ProductTaskOutputInfo
+// as OCI artifacts to an OCI-compliant registry, optionally attaching a cosign-compatible
+// signature envelope as a referrer. Its configuration is supplied at construction time via
+// OCIPublisherParams; the registry, repository-template, referrers-api and sign-key values in
+// flagVals override it for a single RunPublish call. cfgYML is accepted to satisfy
+// distgo.Publisher but unused.
+type ociPublisher struct {
+	params  OCIPublisherParams
+	digests OCIDigests
+}
+
+// NewOCIPublisher returns a distgo.Publisher that pushes dist artifacts as OCI artifacts using
+// params.
+func NewOCIPublisher(params OCIPublisherParams) distgo.Publisher {
+	return &ociPublisher{
+		params:  params,
+		digests: OCIDigests{},
+	}
+}
+
+func (p *ociPublisher) TypeName() (string, error) {
+	return "oci", nil
+}
+
+func (p *ociPublisher) Flags() ([]distgo.PublisherFlag, error) {
+	return []distgo.PublisherFlag{
+		{Name: RegistryFlagName, Description: "OCI-compliant registry host to push to", Type: distgo.StringFlag},
+		{Name: RepositoryTemplateFlagName, Description: "repository name template; \"{{Product}}\" is replaced with the product name", Type: distgo.StringFlag},
+		{Name: ReferrersAPIFlagName, Description: "referrers API mode to use for attaching signatures (auto, v1_1, tag-fallback)", Type: distgo.StringFlag},
+		{Name: SignKeyFlagName, Description: "path to a cosign-compatible private key used to sign the artifact digest", Type: distgo.StringFlag},
+	}, nil
+}
+
+// Digests returns the digests recorded for the artifacts published by the most recent call to
+// RunPublish, keyed by local artifact path.
+func (p *ociPublisher) Digests() OCIDigests {
+	return p.digests
+}
+
+func (p *ociPublisher) RunPublish(productTaskOutputInfo distgo.ProductTaskOutputInfo, cfgYML []byte, flagVals map[distgo.PublisherFlagName]interface{}, dryRun bool, stdout io.Writer) error {
+	p.digests = OCIDigests{}
+	effective := p.params
+	if registryHost, ok := flagVals[RegistryFlagName].(string); ok && registryHost != "" {
+		effective.RegistryHost = registryHost
+	}
+	if repoTemplate, ok := flagVals[RepositoryTemplateFlagName].(string); ok && repoTemplate != "" {
+		effective.RepositoryTemplate = repoTemplate
+	}
+	if referrersAPI, ok := flagVals[ReferrersAPIFlagName].(string); ok && referrersAPI != "" {
+		effective.ReferrersAPI = ReferrersAPIMode(referrersAPI)
+	}
+	if signKey, ok := flagVals[SignKeyFlagName].(string); ok && signKey != "" {
+		effective.SignKey = signKey
+	}
+
+	repository := repositoryForProduct(effective.RepositoryTemplate, string(productTaskOutputInfo.Product.ID))
+	if effective.RegistryHost != "" {
+		repository = effective.RegistryHost + "/" + repository
+	}
+
+	artifactPaths := productTaskOutputInfo.ProductDistArtifactPaths()
+	var distIDs []distgo.DistID
+	for distID := range artifactPaths {
+		distIDs = append(distIDs, distID)
+	}
+	sort.Sort(distgo.ByDistID(distIDs))
+
+	for _, distID := range distIDs {
+		for _, artifactPath := range artifactPaths[distID] {
+			if err := p.publishArtifact(effective, repository, distID, artifactPath, dryRun, stdout); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// digestSidecarPath is the path that the content-addressable digest for artifactPath is recorded
+// at once pushed, so that subsequent tasks that only have the artifact path (and not a reference
+// to this *ociPublisher) can read it back.
+func digestSidecarPath(artifactPath string) string {
+	return artifactPath + ".oci-digest"
+}
+
+func (p *ociPublisher) publishArtifact(params OCIPublisherParams, repository string, distID distgo.DistID, artifactPath string, dryRun bool, stdout io.Writer) error {
+	contents, err := ioutil.ReadFile(artifactPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read artifact %s", artifactPath)
+	}
+	mediaType := artifactType(params, distID, artifactPath)
+
+	if dryRun {
+		fmt.Fprintf(stdout, "[dry run] would push %s (%s, %d bytes) to %s as artifactType %s\n", artifactPath, localDigest(contents), len(contents), repository, mediaType)
+		return nil
+	}
+
+	digest, err := params.Registry.Push(repository, mediaType, contents)
+	if err != nil {
+		return errors.Wrapf(err, "failed to push %s to %s", artifactPath, repository)
+	}
+	p.digests[artifactPath] = digest
+	if err := ioutil.WriteFile(digestSidecarPath(artifactPath), []byte(digest), 0644); err != nil {
+		return errors.Wrapf(err, "failed to record digest for %s", artifactPath)
+	}
+	fmt.Fprintf(stdout, "Pushed %s to %s as %s (artifactType %s)\n", artifactPath, repository, digest, mediaType)
+
+	if params.SignKey != "" {
+		if err := p.attachSignature(params, repository, digest, stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ociPublisher) attachSignature(params OCIPublisherParams, repository, digest string, stdout io.Writer) error {
+	if params.Signer == nil {
+		return errors.Errorf("--sign-key was provided but no signer is configured")
+	}
+	sig, err := params.Signer(params.SignKey, digest)
+	if err != nil {
+		return errors.Wrapf(err, "failed to sign %s", digest)
+	}
+	mode := params.ReferrersAPI
+	if mode == "" {
+		mode = ReferrersAPIAuto
+	}
+	if err := params.Registry.PushReferrer(repository, digest, "application/vnd.dev.cosign.simplesigning.v1+json", sig, mode); err != nil {
+		return errors.Wrapf(err, "failed to attach signature referrer for %s", digest)
+	}
+	fmt.Fprintf(stdout, "Attached signature referrer for %s\n", digest)
+	return nil
+}
+
+// artifactType resolves the OCI artifactType media type for artifactPath produced by distID:
+// params.DistArtifactTypes is checked first (as requested, artifact type is keyed "per DistID"),
+// then params.ArtifactTypes is checked by matching the longest configured suffix against
+// artifactPath -- not filepath.Ext, which only returns the last dot-delimited segment and would
+// never match a compound suffix like ".cdx.json". An artifact matching neither falls back to
+// "application/octet-stream".
+func artifactType(params OCIPublisherParams, distID distgo.DistID, artifactPath string) string {
+	if mediaType, ok := params.DistArtifactTypes[distID]; ok {
+		return mediaType
+	}
+
+	var suffixes []string
+	for suffix := range params.ArtifactTypes {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Slice(suffixes, func(i, j int) bool { return len(suffixes[i]) > len(suffixes[j]) })
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(artifactPath, suffix) {
+			return params.ArtifactTypes[suffix]
+		}
+	}
+	return "application/octet-stream"
+}
+
+func repositoryForProduct(template, productName string) string {
+	const productPlaceholder = "{{Product}}"
+	out := ""
+	for i := 0; i < len(template); {
+		if i+len(productPlaceholder) <= len(template) && template[i:i+len(productPlaceholder)] == productPlaceholder {
+			out += productName
+			i += len(productPlaceholder)
+			continue
+		}
+		out += string(template[i])
+		i++
+	}
+	return out
+}
+
+func localDigest(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,154 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publisher_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/distgo/distgo"
+	"github.com/palantir/distgo/publisher"
+)
+
+// fakeRegistry is an in-memory Registry used to test OCIPublisher without a real OCI registry.
+type fakeRegistry struct {
+	blobs     map[string][]byte
+	referrers map[string][]string
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		blobs:     make(map[string][]byte),
+		referrers: make(map[string][]string),
+	}
+}
+
+func (r *fakeRegistry) Push(repository, mediaType string, contents []byte) (string, error) {
+	digest := "sha256:fakedigest"
+	r.blobs[repository+"@"+digest] = contents
+	return digest, nil
+}
+
+func (r *fakeRegistry) PushReferrer(repository, subjectDigest, referrerMediaType string, contents []byte, mode publisher.ReferrersAPIMode) error {
+	r.referrers[repository+"@"+subjectDigest] = append(r.referrers[repository+"@"+subjectDigest], referrerMediaType)
+	return nil
+}
+
+func TestOCIPublisherPublish(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	artifactPath := path.Join(tmpDir, "foo-0.1.0.tgz")
+	require.NoError(t, ioutil.WriteFile(artifactPath, []byte("fake tgz contents"), 0644))
+
+	registry := newFakeRegistry()
+	pub := publisher.NewOCIPublisher(publisher.OCIPublisherParams{
+		Registry:           registry,
+		RepositoryTemplate: "my-org/{{Product}}",
+		ArtifactTypes: map[string]string{
+			".tgz": "application/vnd.distgo.dist.tgz",
+		},
+		SignKey: "testkey.pem",
+		Signer: func(signKeyPath, digest string) ([]byte, error) {
+			return []byte("signature-for-" + digest), nil
+		},
+	})
+
+	var outputInfo distgo.ProductTaskOutputInfo
+	outputInfo.Product.ID = "foo"
+	outputInfo.AddDistArtifactPaths("tgz", artifactPath)
+
+	buffer := &bytes.Buffer{}
+	err = pub.RunPublish(outputInfo, nil, nil, false, buffer)
+	require.NoError(t, err)
+
+	assert.Equal(t, "fake tgz contents", string(registry.blobs["my-org/foo@sha256:fakedigest"]))
+	assert.Equal(t, []string{"application/vnd.dev.cosign.simplesigning.v1+json"}, registry.referrers["my-org/foo@sha256:fakedigest"])
+	assert.Contains(t, buffer.String(), "Pushed "+artifactPath)
+
+	digestBytes, err := ioutil.ReadFile(artifactPath + ".oci-digest")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:fakedigest", string(digestBytes))
+}
+
+func TestOCIPublisherArtifactTypeMatchesCompoundExtensionAndDistID(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	sbomPath := path.Join(tmpDir, "foo-0.1.0-sbom.cdx.json")
+	require.NoError(t, ioutil.WriteFile(sbomPath, []byte("fake sbom contents"), 0644))
+	tgzPath := path.Join(tmpDir, "foo-0.1.0.tgz")
+	require.NoError(t, ioutil.WriteFile(tgzPath, []byte("fake tgz contents"), 0644))
+
+	registry := newFakeRegistry()
+	pub := publisher.NewOCIPublisher(publisher.OCIPublisherParams{
+		Registry:           registry,
+		RepositoryTemplate: "my-org/{{Product}}",
+		ArtifactTypes: map[string]string{
+			".json":     "application/octet-stream",
+			".cdx.json": "application/vnd.cyclonedx+json",
+		},
+		DistArtifactTypes: map[distgo.DistID]string{
+			"tgz": "application/vnd.distgo.dist.tgz",
+		},
+	})
+
+	var outputInfo distgo.ProductTaskOutputInfo
+	outputInfo.Product.ID = "foo"
+	outputInfo.AddDistArtifactPaths("tgz", tgzPath)
+	outputInfo.AddDistArtifactPaths("sbom-cyclonedx", sbomPath)
+
+	buffer := &bytes.Buffer{}
+	require.NoError(t, pub.RunPublish(outputInfo, nil, nil, false, buffer))
+
+	assert.Contains(t, buffer.String(), "artifactType application/vnd.distgo.dist.tgz")
+	assert.Contains(t, buffer.String(), "artifactType application/vnd.cyclonedx+json")
+	assert.NotContains(t, buffer.String(), "artifactType application/octet-stream")
+}
+
+func TestOCIPublisherRunPublishFlagValsOverrideConstructionParams(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	artifactPath := path.Join(tmpDir, "foo-0.1.0.tgz")
+	require.NoError(t, ioutil.WriteFile(artifactPath, []byte("fake tgz contents"), 0644))
+
+	registry := newFakeRegistry()
+	pub := publisher.NewOCIPublisher(publisher.OCIPublisherParams{
+		Registry:           registry,
+		RepositoryTemplate: "my-org/{{Product}}",
+	})
+
+	var outputInfo distgo.ProductTaskOutputInfo
+	outputInfo.Product.ID = "foo"
+	outputInfo.AddDistArtifactPaths("tgz", artifactPath)
+
+	buffer := &bytes.Buffer{}
+	flagVals := map[distgo.PublisherFlagName]interface{}{
+		publisher.RegistryFlagName: "registry.example.com",
+	}
+	require.NoError(t, pub.RunPublish(outputInfo, nil, flagVals, false, buffer))
+
+	assert.Contains(t, buffer.String(), "registry.example.com/my-org/foo")
+}